@@ -0,0 +1,250 @@
+// Package ast declares the types used to represent an Equilex syntax tree.
+package ast
+
+import (
+	"github.com/utilitywarehouse/equilex"
+)
+
+// Node is implemented by every node in the tree.
+type Node interface {
+	Pos() equilex.Position
+	End() equilex.Position
+}
+
+// Statement is implemented by every statement-level node.
+type Statement interface {
+	Node
+	stmtNode()
+}
+
+// Expr is implemented by every expression-level node.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// File is the root of a parsed Equilex source file.
+type File struct {
+	Statements []Statement
+}
+
+// Pos returns the position of the first statement, or the zero Position
+// if the file is empty.
+func (f *File) Pos() equilex.Position {
+	if len(f.Statements) == 0 {
+		return equilex.Position{}
+	}
+	return f.Statements[0].Pos()
+}
+
+// End returns the position just past the last statement, or the zero
+// Position if the file is empty.
+func (f *File) End() equilex.Position {
+	if len(f.Statements) == 0 {
+		return equilex.Position{}
+	}
+	return f.Statements[len(f.Statements)-1].End()
+}
+
+// Param is a single formal parameter of a Procedure declaration.
+type Param struct {
+	Name string
+	Type equilex.Token // String, Logical, Number or Date
+}
+
+// ProcDecl is a `[public] [external] procedure NAME(params) ... end`
+// declaration.
+type ProcDecl struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Public   bool
+	External bool
+	Name     string
+	Params   []Param
+	Body     []Statement
+}
+
+func (d *ProcDecl) Pos() equilex.Position { return d.StartPos }
+func (d *ProcDecl) End() equilex.Position { return d.EndPos }
+func (d *ProcDecl) stmtNode()             {}
+
+// IfStmt is an `if COND then ... [elseif COND then ...] [else ...] endif`.
+type IfStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Cond    Expr
+	Then    []Statement
+	ElseIfs []ElseIfClause
+	Else    []Statement
+}
+
+// ElseIfClause is a single `elseif COND then ...` arm of an IfStmt.
+type ElseIfClause struct {
+	Cond Expr
+	Body []Statement
+}
+
+func (s *IfStmt) Pos() equilex.Position { return s.StartPos }
+func (s *IfStmt) End() equilex.Position { return s.EndPos }
+func (s *IfStmt) stmtNode()             {}
+
+// WhileStmt is a `while COND ... end`.
+type WhileStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Cond Expr
+	Body []Statement
+}
+
+func (s *WhileStmt) Pos() equilex.Position { return s.StartPos }
+func (s *WhileStmt) End() equilex.Position { return s.EndPos }
+func (s *WhileStmt) stmtNode()             {}
+
+// RepeatStmt is a `repeat ... until COND`.
+type RepeatStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Body  []Statement
+	Until Expr
+}
+
+func (s *RepeatStmt) Pos() equilex.Position { return s.StartPos }
+func (s *RepeatStmt) End() equilex.Position { return s.EndPos }
+func (s *RepeatStmt) stmtNode()             {}
+
+// ForStmt is a `for VAR = FROM to TO [step STEP] ... next`.
+type ForStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Var  string
+	From Expr
+	To   Expr
+	Step Expr // nil if no explicit step clause
+	Body []Statement
+}
+
+func (s *ForStmt) Pos() equilex.Position { return s.StartPos }
+func (s *ForStmt) End() equilex.Position { return s.EndPos }
+func (s *ForStmt) stmtNode()             {}
+
+// SwitchStmt is a `switch TAG case V1, V2 ... [block] ... end`.
+type SwitchStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Tag   Expr
+	Cases []CaseClause
+}
+
+// CaseClause is a single `case V1, V2 ...` arm of a SwitchStmt. The parser
+// always fills Values with at least one expression; a `case` with no values
+// is not parsed as a default arm.
+type CaseClause struct {
+	Values []Expr
+	Body   []Statement
+}
+
+func (s *SwitchStmt) Pos() equilex.Position { return s.StartPos }
+func (s *SwitchStmt) End() equilex.Position { return s.EndPos }
+func (s *SwitchStmt) stmtNode()             {}
+
+// BlockStmt is a `block ... end` grouping used inside a SwitchStmt case.
+type BlockStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Body []Statement
+}
+
+func (s *BlockStmt) Pos() equilex.Position { return s.StartPos }
+func (s *BlockStmt) End() equilex.Position { return s.EndPos }
+func (s *BlockStmt) stmtNode()             {}
+
+// CallStmt invokes an Equilex built-in such as Method, Form, Task, Command,
+// Process, Report or Query.
+type CallStmt struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Kind equilex.Token // e.g. equilex.Method, equilex.Form, equilex.Task
+	Name string
+	Args []Expr
+}
+
+func (s *CallStmt) Pos() equilex.Position { return s.StartPos }
+func (s *CallStmt) End() equilex.Position { return s.EndPos }
+func (s *CallStmt) stmtNode()             {}
+
+// ExprStmt is a bare expression used as a statement, most commonly an
+// assignment (`X = Y`).
+type ExprStmt struct {
+	X Expr
+}
+
+func (s *ExprStmt) Pos() equilex.Position { return s.X.Pos() }
+func (s *ExprStmt) End() equilex.Position { return s.X.End() }
+func (s *ExprStmt) stmtNode()             {}
+
+// Ident is a bare identifier reference.
+type Ident struct {
+	StartPos equilex.Position
+	Name     string
+}
+
+func (x *Ident) Pos() equilex.Position { return x.StartPos }
+func (x *Ident) End() equilex.Position {
+	return equilex.Position{Line: x.StartPos.Line, Column: x.StartPos.Column + len(x.Name), Offset: x.StartPos.Offset + len(x.Name)}
+}
+func (x *Ident) exprNode() {}
+
+// BinaryExpr is `X OP Y`.
+type BinaryExpr struct {
+	Op   equilex.Token
+	X, Y Expr
+}
+
+func (x *BinaryExpr) Pos() equilex.Position { return x.X.Pos() }
+func (x *BinaryExpr) End() equilex.Position { return x.Y.End() }
+func (x *BinaryExpr) exprNode()             {}
+
+// UnaryExpr is `OP X`, e.g. `not X` or `-X`.
+type UnaryExpr struct {
+	StartPos equilex.Position
+	Op       equilex.Token
+	X        Expr
+}
+
+func (x *UnaryExpr) Pos() equilex.Position { return x.StartPos }
+func (x *UnaryExpr) End() equilex.Position { return x.X.End() }
+func (x *UnaryExpr) exprNode()             {}
+
+// ParenExpr is a parenthesised expression `(X)`.
+type ParenExpr struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+	X        Expr
+}
+
+func (x *ParenExpr) Pos() equilex.Position { return x.StartPos }
+func (x *ParenExpr) End() equilex.Position { return x.EndPos }
+func (x *ParenExpr) exprNode()             {}
+
+// Literal is a constant value: a string, integer, decimal, boolean or
+// parsed date/time.
+type Literal struct {
+	StartPos equilex.Position
+	EndPos   equilex.Position
+
+	Kind  equilex.Token // e.g. equilex.StringConstant, equilex.IntegerConstant
+	Raw   string        // the literal as it appeared in the source
+	Value interface{}   // string, int64, float64, bool, time.Time or nil
+}
+
+func (x *Literal) Pos() equilex.Position { return x.StartPos }
+func (x *Literal) End() equilex.Position { return x.EndPos }
+func (x *Literal) exprNode()             {}
@@ -0,0 +1,125 @@
+package ast
+
+// Visitor is implemented by callers of Walk. Visit is called with the node
+// before its children are visited; if it returns nil, Walk does not
+// recurse into the node's children, otherwise Walk uses the returned
+// Visitor for the recursion.
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a syntax tree in depth-first order, calling v.Visit for
+// node and every descendant it has.
+func Walk(v Visitor, node Node) {
+	if node == nil {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *File:
+		for _, s := range n.Statements {
+			Walk(v, s)
+		}
+
+	case *ProcDecl:
+		for _, s := range n.Body {
+			Walk(v, s)
+		}
+
+	case *IfStmt:
+		Walk(v, n.Cond)
+		for _, s := range n.Then {
+			Walk(v, s)
+		}
+		for _, ei := range n.ElseIfs {
+			Walk(v, ei.Cond)
+			for _, s := range ei.Body {
+				Walk(v, s)
+			}
+		}
+		for _, s := range n.Else {
+			Walk(v, s)
+		}
+
+	case *WhileStmt:
+		Walk(v, n.Cond)
+		for _, s := range n.Body {
+			Walk(v, s)
+		}
+
+	case *RepeatStmt:
+		for _, s := range n.Body {
+			Walk(v, s)
+		}
+		Walk(v, n.Until)
+
+	case *ForStmt:
+		Walk(v, n.From)
+		Walk(v, n.To)
+		if n.Step != nil {
+			Walk(v, n.Step)
+		}
+		for _, s := range n.Body {
+			Walk(v, s)
+		}
+
+	case *SwitchStmt:
+		Walk(v, n.Tag)
+		for _, c := range n.Cases {
+			for _, val := range c.Values {
+				Walk(v, val)
+			}
+			for _, s := range c.Body {
+				Walk(v, s)
+			}
+		}
+
+	case *BlockStmt:
+		for _, s := range n.Body {
+			Walk(v, s)
+		}
+
+	case *CallStmt:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+
+	case *ExprStmt:
+		Walk(v, n.X)
+
+	case *BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+
+	case *UnaryExpr:
+		Walk(v, n.X)
+
+	case *ParenExpr:
+		Walk(v, n.X)
+
+	case *Ident, *Literal:
+		// leaves
+
+	default:
+		panic("ast.Walk: unexpected node type")
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a syntax tree in depth-first order, calling f for node
+// and every descendant it has. It stops recursing into a node's children
+// as soon as f returns false for that node.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,47 @@
+package equilex
+
+// ScanMode controls how a Lexer responds to malformed input.
+type ScanMode int
+
+const (
+	// StrictMode is the Lexer's original behaviour: the first malformed
+	// literal, comment or number aborts scanning with an error.
+	StrictMode ScanMode = iota
+	// RecoverMode resynchronises after a problem instead of returning an
+	// error: an unterminated single- or double-quoted literal resyncs at
+	// the end of its line, an unterminated $-quoted literal or block
+	// comment resyncs at EOF, and a
+	// malformed number is emitted as Illegal and scanning continues.
+	// Every problem is appended to Diagnostics instead.
+	RecoverMode
+)
+
+// Severity classifies a Diagnostic.
+type Severity int
+
+const (
+	// SeverityError marks input the Lexer could not make sense of.
+	SeverityError Severity = iota
+	// SeverityWarning marks input that scanned fine but looks suspicious.
+	SeverityWarning
+)
+
+// Diagnostic describes a single problem found while scanning in
+// RecoverMode.
+type Diagnostic struct {
+	Position Position
+	Severity Severity
+	Message  string
+}
+
+// Errors returns every Diagnostic collected so far. It is only populated
+// when the Lexer's Mode is RecoverMode.
+func (s *Lexer) Errors() []Diagnostic {
+	return s.Diagnostics
+}
+
+// report appends a Diagnostic for pos. Callers only invoke it once they've
+// already confirmed Mode == RecoverMode.
+func (s *Lexer) report(pos Position, message string) {
+	s.Diagnostics = append(s.Diagnostics, Diagnostic{Position: pos, Severity: SeverityError, Message: message})
+}
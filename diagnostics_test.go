@@ -0,0 +1,73 @@
+package equilex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestStrictModeStopsAtFirstUnterminatedString(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader(`"unterminated`))
+	_, _, err := l.Scan()
+	assert.Error(err)
+}
+
+func TestRecoverModeResyncsUnterminatedStringAtEOL(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("\"unterminated\nrest = 1"))
+	l.Mode = RecoverMode
+
+	tok, _, err := l.Scan()
+	assert.NoError(err)
+	assert.Equal(Illegal, tok)
+	assert.Len(l.Errors(), 1)
+
+	// the newline was left in place for resync, so it scans next
+	tok, _, err = l.Scan()
+	assert.NoError(err)
+	assert.Equal(NewLine, tok)
+
+	tok, lit, err := l.Scan()
+	assert.NoError(err)
+	assert.Equal(Identifier, tok)
+	assert.Equal("rest", lit)
+}
+
+func TestRecoverModeContinuesPastMalformedNumber(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("1.2.3 ok"))
+	l.Mode = RecoverMode
+
+	tok, _, err := l.Scan()
+	assert.NoError(err)
+	assert.Equal(Illegal, tok)
+	assert.Len(l.Errors(), 1)
+
+	_, _, err = l.Scan() // WS
+	assert.NoError(err)
+
+	tok, lit, err := l.Scan()
+	assert.NoError(err)
+	assert.Equal(Identifier, tok)
+	assert.Equal("ok", lit)
+}
+
+func TestRecoverModeResyncsUnterminatedCommentAtEOF(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("|* never closed"))
+	l.Mode = RecoverMode
+
+	tok, _, err := l.Scan()
+	assert.NoError(err)
+	assert.Equal(Comment, tok)
+	assert.Len(l.Errors(), 1)
+
+	tok, _, err = l.Scan()
+	assert.NoError(err)
+	assert.Equal(EOF, tok)
+}
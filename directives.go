@@ -0,0 +1,95 @@
+package equilex
+
+import "strings"
+
+// directivePrefixes are the sigils a Comment literal's body can begin
+// with to mark it as carrying a Directive rather than being a plain
+// comment, mirroring the `/*! ... */` "special comments" SQL tokenizers
+// such as Vitess use to carry directives for downstream tools.
+var directivePrefixes = []string{"|*!", "|*@equilex:"}
+
+// Directive is a single key[:value] pragma parsed out of a block
+// comment's body, e.g. `nolint:UnusedVar` out of `|*!nolint:UnusedVar*|`,
+// or `reason="..."` out of `|*@equilex:deprecated reason="..."*|`. Value
+// is empty for a bare directive with no value.
+type Directive struct {
+	Prefix string // the sigil that introduced it, e.g. "|*!"
+	Key    string
+	Value  string
+	Raw    string // the full Comment literal it was parsed from
+}
+
+// IsDirective reports whether a Comment token's literal carries one or
+// more Directives, i.e. its body begins with a recognised sigil.
+func IsDirective(lit string) bool {
+	_, _, ok := directiveBody(lit)
+	return ok
+}
+
+// Directives parses every directive out of a Comment token's literal. It
+// takes the literal string Scan/ScanWithPos already returned for a
+// Comment token -- there is no separate Comment type, since Comment is
+// already the name of the Token constant -- and returns nil if lit isn't
+// a directive comment at all.
+func Directives(lit string) []Directive {
+	prefix, body, ok := directiveBody(lit)
+	if !ok {
+		return nil
+	}
+
+	var dirs []Directive
+	for _, field := range splitDirectiveFields(body) {
+		key, value := field, ""
+		if i := strings.IndexAny(field, ":="); i >= 0 {
+			key, value = field[:i], strings.Trim(field[i+1:], `"`)
+		}
+		dirs = append(dirs, Directive{Prefix: prefix, Key: key, Value: value, Raw: lit})
+	}
+	return dirs
+}
+
+// directiveBody strips a recognised sigil and the comment's closing "*|"
+// from lit, returning the sigil and the remaining body.
+func directiveBody(lit string) (prefix, body string, ok bool) {
+	for _, p := range directivePrefixes {
+		if !strings.HasPrefix(lit, p) {
+			continue
+		}
+		body = strings.TrimSuffix(lit, "*|")
+		body = strings.TrimPrefix(body, p)
+		return p, strings.TrimSpace(body), true
+	}
+	return "", "", false
+}
+
+// splitDirectiveFields splits a directive body on whitespace and commas,
+// the way its two examples (`nolint:UnusedVar` and
+// `deprecated reason="..."`) are separated, without splitting inside a
+// "..." quoted value.
+func splitDirectiveFields(body string) []string {
+	var fields []string
+	var cur strings.Builder
+	inQuotes := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			fields = append(fields, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range body {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			cur.WriteRune(r)
+		case !inQuotes && (r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == ','):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+
+	return fields
+}
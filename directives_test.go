@@ -0,0 +1,39 @@
+package equilex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestIsDirective(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsDirective(`|*!nolint:UnusedVar*|`))
+	assert.True(IsDirective(`|*@equilex:deprecated reason="old api"*|`))
+	assert.False(IsDirective(`|* just a comment *|`))
+}
+
+func TestDirectivesNolint(t *testing.T) {
+	assert := assert.New(t)
+
+	dirs := Directives(`|*!nolint:UnusedVar*|`)
+	assert.Equal([]Directive{
+		{Prefix: "|*!", Key: "nolint", Value: "UnusedVar", Raw: `|*!nolint:UnusedVar*|`},
+	}, dirs)
+}
+
+func TestDirectivesEquilexReason(t *testing.T) {
+	assert := assert.New(t)
+
+	raw := `|*@equilex:deprecated reason="use NewThing instead"*|`
+	dirs := Directives(raw)
+	assert.Equal([]Directive{
+		{Prefix: "|*@equilex:", Key: "deprecated", Value: "", Raw: raw},
+		{Prefix: "|*@equilex:", Key: "reason", Value: "use NewThing instead", Raw: raw},
+	}, dirs)
+}
+
+func TestDirectivesNotADirective(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(Directives(`|* just a comment *|`))
+}
@@ -0,0 +1,332 @@
+// Package format re-emits Equilex source with normalized indentation and
+// keyword casing, while preserving every comment and blank line from the
+// input. It is built directly on equilex.Lexer's lossless token stream
+// (see (*equilex.Lexer).ScanLossless), so it round-trips source a full
+// parser doesn't need to understand.
+package format
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/utilitywarehouse/equilex"
+)
+
+// Case controls how keyword tokens are re-cased by Format.
+type Case int
+
+const (
+	// PreserveCase leaves every keyword exactly as it was written.
+	PreserveCase Case = iota
+	// UpperCase re-cases every keyword to upper case.
+	UpperCase
+	// LowerCase re-cases every keyword to lower case.
+	LowerCase
+)
+
+// Options controls Format's output.
+type Options struct {
+	// Indent is the string used for one level of indentation. Defaults
+	// to a single tab.
+	Indent string
+	// KeywordCase controls how keyword tokens (if, endif, method, ...)
+	// are re-cased. Defaults to PreserveCase.
+	KeywordCase Case
+}
+
+func (o Options) withDefaults() Options {
+	if o.Indent == "" {
+		o.Indent = "\t"
+	}
+	return o
+}
+
+// closers maps a block-ending keyword to true; seeing one as the first
+// token of a line dedents that line before it is printed.
+var closers = map[equilex.Token]bool{
+	equilex.EndIf: true,
+	equilex.End:   true,
+	equilex.Until: true,
+	equilex.Next:  true,
+}
+
+// midBlockLabels are tokens that, like closers, dedent the line they
+// start, but then re-indent for the body that follows -- they continue
+// the enclosing block rather than end it.
+var midBlockLabels = map[equilex.Token]bool{
+	equilex.Else:   true,
+	equilex.ElseIf: true,
+	equilex.Case:   true,
+}
+
+// openers are tokens that indent every line up to their matching closer.
+// All but If/Repeat/For close with the single generic End keyword;
+// Format tracks only a running depth, not which opener a given closer
+// matches.
+var openers = map[equilex.Token]bool{
+	equilex.Procedure: true,
+	equilex.If:        true,
+	equilex.While:     true,
+	equilex.Repeat:    true,
+	equilex.For:       true,
+	equilex.Switch:    true,
+	equilex.Block:     true,
+}
+
+// keywords is every reserved word Token, the set KeywordCase applies to.
+var keywords = map[equilex.Token]bool{
+	equilex.String: true, equilex.Logical: true, equilex.Number: true, equilex.Date: true,
+
+	equilex.Subtable: true, equilex.FindRecord: true, equilex.FileOpen: true,
+	equilex.FilePrint: true, equilex.FirstRecord: true, equilex.Method: true,
+	equilex.Text: true, equilex.Lookup: true, equilex.Alert: true,
+	equilex.SetIndex: true, equilex.Execute: true, equilex.MethodSwap: true,
+	equilex.MethodSetup: true, equilex.Process: true, equilex.FormSwap: true,
+	equilex.Form: true, equilex.OptimiseTable: true, equilex.OptimiseTableIndexes: true,
+	equilex.OptimiseDatabase: true, equilex.OptimiseDatabaseIndexes: true,
+	equilex.OptimiseAllDatabases: true, equilex.OptimiseAllDatabasesIndexes: true,
+	equilex.OptimiseDatabaseHelper: true, equilex.ConvertAllDatabases: true,
+	equilex.Command: true, equilex.Task: true, equilex.Shell: true,
+	equilex.Export: true, equilex.Import: true, equilex.EmptyDatabase: true,
+	equilex.Query: true, equilex.ReportPreview: true, equilex.Report: true,
+	equilex.System: true,
+
+	equilex.Public: true, equilex.Procedure: true, equilex.External: true,
+
+	equilex.If: true, equilex.Else: true, equilex.ElseIf: true, equilex.EndIf: true,
+	equilex.While: true, equilex.End: true, equilex.Repeat: true, equilex.Until: true,
+	equilex.For: true, equilex.Next: true, equilex.Step: true, equilex.Then: true,
+
+	equilex.Block: true, equilex.Switch: true, equilex.Case: true,
+
+	equilex.Not: true, equilex.And: true, equilex.Or: true, equilex.Xor: true,
+	equilex.True: true, equilex.False: true, equilex.Today: true, equilex.SysError: true,
+}
+
+func recase(lit string, tok equilex.Token, c Case) string {
+	if c == PreserveCase || !keywords[tok] {
+		return lit
+	}
+	if c == UpperCase {
+		return strings.ToUpper(lit)
+	}
+	return strings.ToLower(lit)
+}
+
+// needsSpace reports whether a space belongs between prev and cur when
+// neither has already forced a line break.
+func needsSpace(prev, cur equilex.Token) bool {
+	switch cur {
+	case equilex.Comma, equilex.Dot, equilex.Semicolon, equilex.RightParen, equilex.RightSquare:
+		return false
+	case equilex.LeftParen:
+		// Tight for call-style `Name(...)`, spaced for grouping and
+		// control-flow parens like `if (a and b) then`.
+		return prev != equilex.Identifier
+	}
+	switch prev {
+	case equilex.LeftParen, equilex.LeftSquare, equilex.Dot:
+		return false
+	}
+	return true
+}
+
+// renderedLine is one physical output line, either already fully rendered
+// (text) or, for a simple `ident = expr` assignment, split into the part
+// before "=" (lhs) and from "=" onward (rhs) so alignAssignments can pad
+// lhs across a run of consecutive assignments.
+type renderedLine struct {
+	depth    int
+	isAssign bool
+	lhs, rhs string
+	text     string
+}
+
+// Format reads Equilex source from r and writes a re-indented, optionally
+// re-cased, copy to w. Every comment and blank line in r is preserved.
+func Format(r io.Reader, w io.Writer, opts Options) error {
+	opts = opts.withDefaults()
+
+	lines, err := render(r, opts)
+	if err != nil {
+		return err
+	}
+	alignAssignments(lines)
+
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		text := l.text
+		if l.isAssign {
+			text = l.lhs + " " + l.rhs
+		}
+		if _, err := bw.WriteString(text); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString("\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func render(r io.Reader, opts Options) ([]*renderedLine, error) {
+	lex := equilex.NewLexer(r)
+
+	var lines []*renderedLine
+	depth := 0
+
+	var cur strings.Builder
+	var prevTok equilex.Token
+	atLineStart := true
+
+	// assignState tracks whether the current line so far looks like a
+	// bare `ident = ...` statement: 0 = no, 1 = saw a leading
+	// Identifier, 2 = confirmed (saw the following Equals).
+	assignState := 0
+	lhsEnd := 0
+
+	flushLine := func() {
+		l := &renderedLine{depth: depth}
+		if assignState == 2 {
+			l.isAssign = true
+			l.lhs = cur.String()[:lhsEnd]
+			l.rhs = cur.String()[lhsEnd:]
+		} else {
+			l.text = cur.String()
+		}
+		lines = append(lines, l)
+		cur.Reset()
+		assignState = 0
+		atLineStart = true
+	}
+
+	emitIndent := func() {
+		for i := 0; i < depth; i++ {
+			cur.WriteString(opts.Indent)
+		}
+	}
+
+	for {
+		t, err := lex.ScanLossless()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, triv := range t.Leading {
+			switch triv.Tok {
+			case equilex.NewLine:
+				n := strings.Count(triv.Lit, "\n") + strings.Count(triv.Lit, "\r") - strings.Count(triv.Lit, "\r\n") - 1
+				if len(lines) == 0 {
+					// At the start of the file no line precedes this run,
+					// so every newline in it ends a blank line.
+					n++
+				}
+				for i := 0; i < n; i++ {
+					lines = append(lines, &renderedLine{depth: depth, text: ""})
+				}
+			case equilex.Comment:
+				emitIndent()
+				cur.WriteString(triv.Lit)
+				flushLine()
+			}
+		}
+
+		// Checked only now, so comments and blank lines after the last
+		// token (the EOF token's Leading trivia) are kept too.
+		if t.Tok == equilex.EOF {
+			break
+		}
+
+		if midBlockLabels[t.Tok] || closers[t.Tok] {
+			depth--
+			if depth < 0 {
+				depth = 0
+			}
+		}
+
+		needSpace := false
+		if atLineStart {
+			emitIndent()
+		} else {
+			needSpace = needsSpace(prevTok, t.Tok)
+		}
+
+		if assignState == 1 && t.Tok == equilex.Equals {
+			assignState = 2
+			lhsEnd = cur.Len()
+			needSpace = false // Format() rejoins lhs+" "+rhs with exactly one space
+		} else if atLineStart && t.Tok == equilex.Identifier {
+			assignState = 1
+		} else if assignState != 2 {
+			assignState = 0
+		}
+
+		if needSpace {
+			cur.WriteString(" ")
+		}
+
+		cur.WriteString(recase(t.Lit, t.Tok, opts.KeywordCase))
+		atLineStart = false
+		prevTok = t.Tok
+
+		if midBlockLabels[t.Tok] {
+			depth++
+		}
+
+		for _, triv := range t.Trailing {
+			switch triv.Tok {
+			case equilex.Comment:
+				cur.WriteString(" ")
+				cur.WriteString(triv.Lit)
+			case equilex.NewLine:
+				flushLine()
+				if n := strings.Count(triv.Lit, "\n") + strings.Count(triv.Lit, "\r") - strings.Count(triv.Lit, "\r\n") - 1; n > 0 {
+					for i := 0; i < n; i++ {
+						lines = append(lines, &renderedLine{depth: depth, text: ""})
+					}
+				}
+			}
+		}
+
+		if openers[t.Tok] {
+			depth++
+		}
+	}
+
+	if cur.Len() > 0 || assignState != 0 {
+		flushLine()
+	}
+
+	return lines, nil
+}
+
+// alignAssignments pads the left-hand side of every simple `ident = expr`
+// line so that the "=" of consecutive assignments at the same depth lines
+// up in a column, the way a human aligning a block of field assignments
+// by hand would.
+func alignAssignments(lines []*renderedLine) {
+	i := 0
+	for i < len(lines) {
+		if !lines[i].isAssign {
+			i++
+			continue
+		}
+
+		j := i
+		width := 0
+		depth := lines[i].depth
+		for j < len(lines) && lines[j].isAssign && lines[j].depth == depth {
+			if n := len(lines[j].lhs); n > width {
+				width = n
+			}
+			j++
+		}
+
+		for k := i; k < j; k++ {
+			lines[k].lhs += strings.Repeat(" ", width-len(lines[k].lhs))
+		}
+
+		i = j
+	}
+}
@@ -0,0 +1,88 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatIndentsBlocks(t *testing.T) {
+	assert := assert.New(t)
+
+	src := `procedure Foo()
+if x > 0 then
+method Bar()
+endif
+end
+`
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{Indent: "  "}))
+
+	want := "procedure Foo()\n  if x > 0 then\n    method Bar()\n  endif\nend\n"
+	assert.Equal(want, out.String())
+}
+
+func TestFormatPreservesComments(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "| a leading comment\nx = 1\n"
+
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{Indent: "  "}))
+
+	assert.Contains(out.String(), "| a leading comment")
+}
+
+func TestFormatAlignsConsecutiveAssignments(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "x = 1\nfoo = 2\n"
+
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{Indent: "  "}))
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	assert.Len(lines, 2)
+
+	eq0 := strings.Index(lines[0], "=")
+	eq1 := strings.Index(lines[1], "=")
+	assert.Equal(eq0, eq1)
+}
+
+func TestFormatUpperCasesKeywords(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "if x > 0 then\nendif\n"
+
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{KeywordCase: UpperCase}))
+
+	assert.True(strings.HasPrefix(out.String(), "IF "))
+	assert.Contains(out.String(), "ENDIF")
+}
+
+func TestFormatPreservesBlankLineBetweenStatements(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "x = 1\n\ny = 2\n"
+
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{Indent: "  "}))
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	assert.Equal([]string{"x = 1", "", "y = 2"}, lines)
+}
+
+func TestFormatPreservesBlankLinesAfterTrailingComment(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "x = 1 |* trailing *|\n\n\ny = 2\n"
+
+	var out bytes.Buffer
+	assert.NoError(Format(strings.NewReader(src), &out, Options{Indent: "  "}))
+
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	assert.Equal([]string{"x = 1 |* trailing *|", "", "", "y = 2"}, lines)
+}
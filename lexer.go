@@ -223,14 +223,81 @@ const (
 	// Doesn't matter until/unless we try to parse
 )
 
+// Position identifies a location in Equilex source by a 1-based line and
+// column and a 0-based byte offset from the start of the input.
+type Position struct {
+	Line   int
+	Column int
+	Offset int
+}
+
+// String returns the position in "line:column" form.
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the range of source covered by a single token: Start is the
+// position of its first rune, End is the position just past its last rune.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// tabWidth is the column width a '\t' advances to the next multiple of.
+const tabWidth = 8
+
 // Lexer is a lexical analyser of Equinox source code.
 type Lexer struct {
 	r *bufio.Reader
+
+	// buf holds the whole input when the Lexer was built by NewBytesLexer
+	// (or by NewLexer, which now pre-reads onto it), so that ScanRef can
+	// scan and return literals without going through r at all. It is nil
+	// for a Lexer built any other way, in which case ScanRef is unusable.
+	buf []byte
+
+	// pos is the position of the rune that will be returned by the next
+	// call to read.
+	pos Position
+
+	// prevPos/prevCR hold the state before the last read, so a single
+	// unread can restore it. This mirrors the one-rune lookback that
+	// bufio.Reader.UnreadRune itself supports.
+	prevPos Position
+	prevCR  bool
+
+	// cr is true when the previous rune read was '\r', so that a
+	// following '\n' is treated as part of the same "\r\n" newline
+	// rather than counted again.
+	cr bool
+
+	// pending buffers a single token ScanLossless has already read from
+	// the lexer but not yet handed back to its caller.
+	pending *pendingTok
+
+	// Mode controls how malformed input is handled; it defaults to the
+	// zero value, StrictMode.
+	Mode ScanMode
+	// Diagnostics accumulates every problem found while scanning in
+	// RecoverMode. Use Errors to read it back.
+	Diagnostics []Diagnostic
 }
 
 // NewLexer returns a new lexical analyser, given a reader that provides equinox source in UTF8.
+//
+// NewLexer reads r to completion up front rather than streaming it, so that
+// buf is populated and ScanRef is available alongside Scan/ScanWithPos; see
+// NewBytesLexer for constructing a Lexer directly from an in-memory buffer
+// without going through an io.Reader at all. If r fails to read fully, the
+// Lexer falls back to scanning whatever was read via the bufio.Reader alone
+// (the partial bytes io.ReadAll already drained from r are replayed first),
+// and ScanRef is unusable (buf stays nil).
 func NewLexer(r io.Reader) *Lexer {
-	return &Lexer{r: bufio.NewReader(r)}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return &Lexer{r: bufio.NewReader(io.MultiReader(bytes.NewReader(b), r)), pos: Position{Line: 1, Column: 1, Offset: 0}}
+	}
+	return NewBytesLexer(b)
 }
 
 // Scan returns the next Token and corresponding string literal
@@ -328,6 +395,7 @@ func (s *Lexer) scanWhitespace() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) scanSingleQuotedLiteral() (tok Token, lit string, err error) {
+	start := s.pos
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
@@ -344,15 +412,19 @@ func (s *Lexer) scanSingleQuotedLiteral() (tok Token, lit string, err error) {
 		}
 
 		if isDate && isTime {
-			return Illegal, "", fmt.Errorf("malformed date or time '%v' next char is '%v'\n", buf.String(), ch)
+			return s.unterminated(start, buf.String(), fmt.Sprintf("malformed date or time '%v' next char is '%v'\n", buf.String(), ch))
 		}
 
 		switch ch {
 		case '\'':
 			buf.WriteRune(ch)
 			return DateOrTimeConstant, buf.String(), nil
-		case '\n':
-			return Illegal, "", fmt.Errorf("unclosed single quote. (TODO: deal with this better)\nbuffer is '%v' and next char is `%v`\n", buf.String(), ch)
+		case '\n', eof:
+			if ch == '\n' {
+				// resync at end-of-line: leave the newline for scanNewline
+				s.unread()
+			}
+			return s.unterminated(start, buf.String(), fmt.Sprintf("unclosed single quote starting at %s (TODO: deal with this better)\nbuffer is '%v'\n", start, buf.String()))
 		default:
 			buf.WriteRune(ch)
 		}
@@ -360,6 +432,7 @@ func (s *Lexer) scanSingleQuotedLiteral() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) scanDoubleQuotedLiteral() (tok Token, lit string, err error) {
+	start := s.pos
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
@@ -369,8 +442,12 @@ func (s *Lexer) scanDoubleQuotedLiteral() (tok Token, lit string, err error) {
 		case '"':
 			buf.WriteRune(ch)
 			return StringConstant, buf.String(), nil
-		case '\n':
-			return Illegal, "", fmt.Errorf("unclosed double quote. (TODO: deal with this better)\nbuffer is '%v' and next char is `%v`\n", buf.String(), ch)
+		case '\n', eof:
+			if ch == '\n' {
+				// resync at end-of-line: leave the newline for scanNewline
+				s.unread()
+			}
+			return s.unterminated(start, buf.String(), fmt.Sprintf("unclosed double quote starting at %s (TODO: deal with this better)\nbuffer is '%v'\n", start, buf.String()))
 		default:
 			buf.WriteRune(ch)
 		}
@@ -378,6 +455,7 @@ func (s *Lexer) scanDoubleQuotedLiteral() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) scanDollarQuotedLiteral() (tok Token, lit string, err error) {
+	start := s.pos
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
@@ -388,17 +466,32 @@ func (s *Lexer) scanDollarQuotedLiteral() (tok Token, lit string, err error) {
 			buf.WriteRune(ch)
 			return StringMultilineConstant, buf.String(), nil
 		case eof:
-			return Illegal, "", fmt.Errorf("unclosed double quote. (TODO: deal with this better)\nbuffer is '%v' and next char is `%v`\n", buf.String(), ch)
+			// resync at EOF: there's nothing left to push back
+			return s.unterminated(start, buf.String(), fmt.Sprintf("unclosed $ quote starting at %s (TODO: deal with this better)\nbuffer is '%v'\n", start, buf.String()))
 		default:
 			buf.WriteRune(ch)
 		}
 	}
 }
 
+// unterminated handles a quoted literal that ran off the end
+// of its line or the file without a closing delimiter. In RecoverMode it
+// records a Diagnostic at start and returns the partial text as Illegal so
+// scanning can continue; in StrictMode it returns the original error.
+func (s *Lexer) unterminated(start Position, partial, message string) (Token, string, error) {
+	if s.Mode == RecoverMode {
+		s.report(start, message)
+		return Illegal, partial, nil
+	}
+	return Illegal, "", fmt.Errorf("%s", message)
+}
+
 func (s *Lexer) scanComment() (tok Token, lit string, err error) {
 	peeked, err := s.r.Peek(2)
 	if err != nil {
-		return Illegal, "", err
+		// Fewer than 2 bytes left (e.g. a lone trailing '|'): it can
+		// only be a single-line comment.
+		return s.scanSingleLineComment()
 	}
 
 	if bytes.Equal([]byte("|*"), peeked) {
@@ -428,31 +521,46 @@ func (s *Lexer) scanSingleLineComment() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) scanStandardComment() (tok Token, lit string, err error) {
+	start := s.pos
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
 	nest := 0
 	for {
-		if ch := s.read(); ch == eof {
-			return Illegal, "", fmt.Errorf("truncated file?:\n\n%v\n\n", string(buf.Bytes()))
-		} else if ch == '|' && peek1(s.r) == '*' {
-			buf.WriteRune(ch)
-			nest++
-		} else if ch == '*' && peek1(s.r) == '|' && buf.Len() > 1 {
+		ch := s.read()
+		switch {
+		case ch == eof:
+			// resync at EOF: a truncated comment runs to the end of input
+			message := fmt.Sprintf("truncated file? unterminated comment starting at %s:\n\n%v\n\n", start, buf.String())
+			if s.Mode == RecoverMode {
+				s.report(start, message)
+				return Comment, buf.String(), nil
+			}
+			return Illegal, "", fmt.Errorf("%s", message)
+
+		case ch == '|':
 			buf.WriteRune(ch)
-			if nest == 0 {
-				s.read()
-				buf.WriteRune('|')
-				break
-			} else {
+			if b, ok := peek1(s.r); ok && b == '*' {
+				nest++
+			}
+
+		case ch == '*' && buf.Len() > 1:
+			if b, ok := peek1(s.r); ok && b == '|' {
+				buf.WriteRune(ch)
+				if nest == 0 {
+					s.read()
+					buf.WriteRune('|')
+					return Comment, buf.String(), nil
+				}
 				nest--
+				continue
 			}
-		} else {
+			buf.WriteRune(ch)
+
+		default:
 			buf.WriteRune(ch)
 		}
 	}
-
-	return Comment, buf.String(), nil
 }
 
 func (s *Lexer) scanNewline() (tok Token, lit string, err error) {
@@ -474,10 +582,12 @@ func (s *Lexer) scanNewline() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) scanNumber() (tok Token, lit string, err error) {
+	start := s.pos
 	var buf bytes.Buffer
 	buf.WriteRune(s.read())
 
 	token := IntegerConstant
+	malformed := false
 
 	for {
 		ch := s.read()
@@ -486,6 +596,11 @@ func (s *Lexer) scanNumber() (tok Token, lit string, err error) {
 			buf.WriteRune(ch)
 			if token == IntegerConstant {
 				token = DecimalConstant
+			} else if s.Mode == RecoverMode {
+				// A second '.': keep consuming the rest of the
+				// number-like run so the whole thing becomes one
+				// Illegal token, instead of aborting here.
+				malformed = true
 			} else {
 				return Illegal, "", fmt.Errorf("malformed number? : '%s' with next char '%v'", buf.String(), string(ch))
 			}
@@ -493,6 +608,10 @@ func (s *Lexer) scanNumber() (tok Token, lit string, err error) {
 			buf.WriteRune(ch)
 		default:
 			s.unread()
+			if malformed {
+				s.report(start, fmt.Sprintf("malformed number: %q", buf.String()))
+				return Illegal, buf.String(), nil
+			}
 			return token, buf.String(), nil
 		}
 	}
@@ -658,32 +777,84 @@ func (s *Lexer) scanIdentifier() (tok Token, lit string, err error) {
 }
 
 func (s *Lexer) read() rune {
-	ch, _, err := s.r.ReadRune()
+	ch, size, err := s.r.ReadRune()
 	if err != nil {
 		return eof
 	}
+
+	s.prevPos = s.pos
+	s.prevCR = s.cr
+	s.advance(ch, size)
+
 	return ch
 }
 
-func peek1(r *bufio.Reader) byte {
-	bytes, err := r.Peek(1)
+// advance moves pos past a rune of the given byte size, tracking line and
+// column the way most source tools do: '\n' (on its own, or the second half
+// of a "\r\n" pair) starts a new line, a lone '\r' also starts a new line,
+// and '\t' advances to the next tabWidth column stop.
+func (s *Lexer) advance(ch rune, size int) {
+	s.pos.Offset += size
+
+	switch ch {
+	case '\n':
+		if s.cr {
+			s.cr = false
+			break
+		}
+		s.pos.Line++
+		s.pos.Column = 1
+	case '\r':
+		s.pos.Line++
+		s.pos.Column = 1
+		s.cr = true
+	case '\t':
+		s.cr = false
+		s.pos.Column += tabWidth - ((s.pos.Column - 1) % tabWidth)
+	default:
+		s.cr = false
+		s.pos.Column++
+	}
+}
+
+// peek1 returns the next unread byte without consuming it. ok is false at
+// EOF or on any other read error, in which case b is the zero byte.
+func peek1(r *bufio.Reader) (b byte, ok bool) {
+	peeked, err := r.Peek(1)
 	if err != nil {
-		panic(err)
+		return 0, false
 	}
-	return bytes[0]
+	return peeked[0], true
 }
 
-func (s *Lexer) unread() {
+// unread pushes the last-read rune back onto the input so the next read
+// returns it again. It returns an error instead of panicking if there is
+// nothing to unread, mirroring bufio.Reader.UnreadRune's own one-rune
+// lookback limit -- callers of unread never need that error in practice,
+// since every unread in this file immediately follows a read, but it is
+// there rather than a panic so a bug here degrades instead of crashing
+// whatever embeds the lexer.
+func (s *Lexer) unread() error {
 	err := s.r.UnreadRune()
 	if err != nil {
-		panic(err)
+		return err
 	}
+	s.pos = s.prevPos
+	s.cr = s.prevCR
+	return nil
 }
 
-func isWhitespace(ch rune) bool { return ch == ' ' || ch == '\t' }
-
-func isLetter(ch rune) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') }
+// ScanWithPos behaves exactly like Scan, but additionally reports the Span
+// of source the returned token covers. For multi-rune tokens such as
+// Comment, StringConstant and StringMultilineConstant this gives both the
+// start and end of the literal; for single-rune tokens (including Illegal)
+// Start and End bracket the one offending or matched rune.
+func (s *Lexer) ScanWithPos() (tok Token, lit string, span Span, err error) {
+	start := s.pos
+	tok, lit, err = s.Scan()
+	return tok, lit, Span{Start: start, End: s.pos}, err
+}
 
-func isDigit(ch rune) bool { return (ch >= '0' && ch <= '9') }
+func isWhitespace(ch rune) bool { return ch == ' ' || ch == '\t' }
 
 var eof = rune(0)
@@ -0,0 +1,321 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/utilitywarehouse/equilex"
+	"github.com/utilitywarehouse/equilex/ast"
+)
+
+// parseExpr parses a full expression, at the loosest precedence level.
+//
+// Precedence, loosest to tightest:
+//
+//	or, xor
+//	and
+//	not (prefix)
+//	=, <, >
+//	+, -
+//	*, /
+//	^ (right associative)
+//	& (string concatenation)
+//	unary -
+//	primary
+func (p *parser) parseExpr() (ast.Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (ast.Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.Or && t.tok != equilex.Xor {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: t.tok, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseAnd() (ast.Expr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.And {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: t.tok, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseNot() (ast.Expr, error) {
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok != equilex.Not {
+		return p.parseComparison()
+	}
+	p.next()
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.UnaryExpr{StartPos: t.span.Start, Op: equilex.Not, X: x}, nil
+}
+
+func (p *parser) parseComparison() (ast.Expr, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		switch t.tok {
+		case equilex.Equals, equilex.LeftAngle, equilex.RightAngle:
+		default:
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: t.tok, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseAdditive() (ast.Expr, error) {
+	x, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.Plus && t.tok != equilex.Minus {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: t.tok, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseMultiplicative() (ast.Expr, error) {
+	x, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.Multiply && t.tok != equilex.Divide {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: t.tok, X: x, Y: y}
+	}
+}
+
+// parsePower parses `^`, right associative: a ^ b ^ c is a ^ (b ^ c).
+func (p *parser) parsePower() (ast.Expr, error) {
+	x, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok != equilex.Power {
+		return x, nil
+	}
+	p.next()
+
+	y, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.BinaryExpr{Op: equilex.Power, X: x, Y: y}, nil
+}
+
+func (p *parser) parseConcat() (ast.Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.Ampersand {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &ast.BinaryExpr{Op: equilex.Ampersand, X: x, Y: y}
+	}
+}
+
+func (p *parser) parseUnary() (ast.Expr, error) {
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok != equilex.Minus {
+		return p.parsePrimary()
+	}
+	p.next()
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.UnaryExpr{StartPos: t.span.Start, Op: equilex.Minus, X: x}, nil
+}
+
+func (p *parser) parsePrimary() (ast.Expr, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.tok {
+	case equilex.LeftParen:
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		rparen, err := p.expect(equilex.RightParen)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ParenExpr{StartPos: t.span.Start, EndPos: rparen.span.End, X: x}, nil
+
+	case equilex.Identifier:
+		return &ast.Ident{StartPos: t.span.Start, Name: t.lit}, nil
+
+	case equilex.StringConstant, equilex.StringMultilineConstant,
+		equilex.IntegerConstant, equilex.DecimalConstant,
+		equilex.DateOrTimeConstant, equilex.True, equilex.False,
+		equilex.Today:
+		return literalFromToken(t)
+
+	default:
+		return nil, fmt.Errorf("%s: unexpected token %q while parsing an expression", t.span.Start, t.lit)
+	}
+}
+
+// literalFromToken turns a scanned constant token into an *ast.Literal
+// with a typed Go value: string, int64, float64, bool or time.Time, or nil
+// for Today.
+func literalFromToken(t token) (*ast.Literal, error) {
+	lit := &ast.Literal{StartPos: t.span.Start, EndPos: t.span.End, Kind: t.tok, Raw: t.lit}
+
+	switch t.tok {
+	case equilex.StringConstant:
+		lit.Value = strings.Trim(t.lit, `"`)
+
+	case equilex.StringMultilineConstant:
+		lit.Value = strings.Trim(t.lit, "$")
+
+	case equilex.IntegerConstant:
+		v, err := strconv.ParseInt(t.lit, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed integer literal %q: %w", t.span.Start, t.lit, err)
+		}
+		lit.Value = v
+
+	case equilex.DecimalConstant:
+		v, err := strconv.ParseFloat(t.lit, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed decimal literal %q: %w", t.span.Start, t.lit, err)
+		}
+		lit.Value = v
+
+	case equilex.DateOrTimeConstant:
+		v, err := parseDateOrTime(strings.Trim(t.lit, "'"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: malformed date or time literal %q: %w", t.span.Start, t.lit, err)
+		}
+		lit.Value = v
+
+	case equilex.True:
+		lit.Value = true
+
+	case equilex.False:
+		lit.Value = false
+
+	case equilex.Today:
+		lit.Value = nil
+	}
+
+	return lit, nil
+}
+
+// dateLayouts are the date/time formats a `'...'` DateOrTimeConstant may
+// use. Equilex dates are '-' separated, times are ':' separated; an empty
+// body between the quotes is a valid "empty" date or time.
+var dateLayouts = []string{
+	"2006-01-02",
+	"15:04:05",
+	"15:04",
+}
+
+func parseDateOrTime(body string) (time.Time, error) {
+	if body == "" {
+		return time.Time{}, nil
+	}
+	var firstErr error
+	for _, layout := range dateLayouts {
+		if v, err := time.Parse(layout, body); err == nil {
+			return v, nil
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
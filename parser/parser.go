@@ -0,0 +1,112 @@
+// Package parser turns Equilex source into an *ast.File using a
+// recursive-descent parser built directly on top of equilex.Lexer.
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/utilitywarehouse/equilex"
+	"github.com/utilitywarehouse/equilex/ast"
+)
+
+// token is a single lexer result with whitespace, comments and newlines
+// already filtered out.
+type token struct {
+	tok  equilex.Token
+	lit  string
+	span equilex.Span
+}
+
+// parser wraps a *equilex.Lexer with the next/back/expect helpers used by
+// the recursive-descent rules in statements.go and expr.go.
+type parser struct {
+	lex *equilex.Lexer
+
+	// buf holds every significant token read so far, so back() can
+	// rewind; pos is the index of the next token next() will hand out.
+	buf []token
+	pos int
+}
+
+func newParser(lex *equilex.Lexer) *parser {
+	return &parser{lex: lex}
+}
+
+// next returns the next significant token, skipping whitespace, newlines
+// and comments, and advances the parser past it.
+func (p *parser) next() (token, error) {
+	if p.pos < len(p.buf) {
+		t := p.buf[p.pos]
+		p.pos++
+		return t, nil
+	}
+
+	for {
+		tok, lit, span, err := p.lex.ScanWithPos()
+		if err != nil {
+			return token{}, fmt.Errorf("%s: %w", span.Start, err)
+		}
+		switch tok {
+		case equilex.WS, equilex.NewLine, equilex.Comment:
+			continue
+		}
+
+		t := token{tok: tok, lit: lit, span: span}
+		p.buf = append(p.buf, t)
+		p.pos++
+		return t, nil
+	}
+}
+
+// back rewinds the parser by one token, so the next call to next returns
+// the token just returned. Only a single step of lookback is supported.
+func (p *parser) back() {
+	if p.pos == 0 {
+		panic("parser: back() called with nothing to rewind")
+	}
+	p.pos--
+}
+
+// peek returns the next significant token without consuming it.
+func (p *parser) peek() (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return token{}, err
+	}
+	p.back()
+	return t, nil
+}
+
+// expect consumes the next token and errors if it isn't of kind want.
+func (p *parser) expect(want equilex.Token) (token, error) {
+	t, err := p.next()
+	if err != nil {
+		return token{}, err
+	}
+	if t.tok != want {
+		return token{}, fmt.Errorf("%s: expected token %d, got %q (token %d)", t.span.Start, want, t.lit, t.tok)
+	}
+	return t, nil
+}
+
+// expectIdentLit consumes the next token and errors unless it is an
+// Identifier whose literal matches lit, case-insensitively. It is used for
+// words such as "to" that equilex.Lexer does not reserve as keywords.
+func (p *parser) expectIdentLit(lit string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.tok != equilex.Identifier || !strings.EqualFold(t.lit, lit) {
+		return fmt.Errorf("%s: expected %q, got %q", t.span.Start, lit, t.lit)
+	}
+	return nil
+}
+
+// ParseFile parses a complete Equilex source file into an *ast.File.
+func ParseFile(r io.Reader) (*ast.File, error) {
+	p := newParser(equilex.NewLexer(r))
+	return p.parseFile()
+}
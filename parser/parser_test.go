@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/utilitywarehouse/equilex"
+	"github.com/utilitywarehouse/equilex/ast"
+)
+
+func TestParseProcDeclWithIfAndCall(t *testing.T) {
+	assert := assert.New(t)
+
+	src := `
+public procedure DoThing(x Number)
+if x > 0 then
+method Frobnicate(x, "ok")
+else
+method Frobnicate(x, "bad")
+endif
+end
+`
+	f, err := ParseFile(strings.NewReader(src))
+	assert.NoError(err)
+	assert.Len(f.Statements, 1)
+
+	decl, ok := f.Statements[0].(*ast.ProcDecl)
+	assert.True(ok)
+	assert.True(decl.Public)
+	assert.False(decl.External)
+	assert.Equal("DoThing", decl.Name)
+	assert.Equal([]ast.Param{{Name: "x", Type: equilex.Number}}, decl.Params)
+	assert.Len(decl.Body, 1)
+
+	ifStmt, ok := decl.Body[0].(*ast.IfStmt)
+	assert.True(ok)
+	assert.Len(ifStmt.Then, 1)
+	assert.Len(ifStmt.Else, 1)
+
+	call, ok := ifStmt.Then[0].(*ast.CallStmt)
+	assert.True(ok)
+	assert.Equal(equilex.Method, call.Kind)
+	assert.Equal("Frobnicate", call.Name)
+	assert.Len(call.Args, 2)
+}
+
+func TestParseExprPrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ParseFile(strings.NewReader("x = 1 + 2 * 3"))
+	assert.NoError(err)
+	assert.Len(f.Statements, 1)
+
+	stmt := f.Statements[0].(*ast.ExprStmt)
+	assign := stmt.X.(*ast.BinaryExpr)
+	assert.Equal(equilex.Equals, assign.Op)
+
+	rhs := assign.Y.(*ast.BinaryExpr)
+	assert.Equal(equilex.Plus, rhs.Op)
+
+	mul := rhs.Y.(*ast.BinaryExpr)
+	assert.Equal(equilex.Multiply, mul.Op)
+}
+
+func TestParseForLoop(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ParseFile(strings.NewReader("for i = 1 to 10 step 2\nmethod Tick()\nnext"))
+	assert.NoError(err)
+
+	forStmt := f.Statements[0].(*ast.ForStmt)
+	assert.Equal("i", forStmt.Var)
+	assert.NotNil(forStmt.Step)
+	assert.Len(forStmt.Body, 1)
+}
@@ -0,0 +1,511 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/utilitywarehouse/equilex"
+	"github.com/utilitywarehouse/equilex/ast"
+)
+
+// parseFile parses a sequence of top-level statements until EOF.
+func (p *parser) parseFile() (*ast.File, error) {
+	f := &ast.File{}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok == equilex.EOF {
+			return f, nil
+		}
+
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		f.Statements = append(f.Statements, stmt)
+	}
+}
+
+// parseStatement parses a single statement, dispatching on its leading
+// token.
+func (p *parser) parseStatement() (ast.Statement, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.tok {
+	case equilex.Public, equilex.External, equilex.Procedure:
+		p.back()
+		return p.parseProcDecl()
+	case equilex.If:
+		return p.parseIf(t)
+	case equilex.While:
+		return p.parseWhile(t)
+	case equilex.Repeat:
+		return p.parseRepeat(t)
+	case equilex.For:
+		return p.parseFor(t)
+	case equilex.Switch:
+		return p.parseSwitch(t)
+	case equilex.Block:
+		return p.parseBlock(t)
+	case equilex.Method, equilex.Form, equilex.Task, equilex.Command,
+		equilex.Process, equilex.Report, equilex.Query:
+		return p.parseCall(t)
+	default:
+		p.back()
+		return p.parseExprStatement()
+	}
+}
+
+// parseBlockBody parses statements until one of the given terminator
+// tokens is seen (without consuming it).
+func (p *parser) parseBlockBody(terminators ...equilex.Token) ([]ast.Statement, error) {
+	var stmts []ast.Statement
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		for _, term := range terminators {
+			if t.tok == term {
+				return stmts, nil
+			}
+		}
+		if t.tok == equilex.EOF {
+			return nil, fmt.Errorf("%s: unexpected EOF in block", t.span.Start)
+		}
+
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+func (p *parser) parseProcDecl() (*ast.ProcDecl, error) {
+	start, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+
+	decl := &ast.ProcDecl{StartPos: start.span.Start}
+
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok == equilex.Public {
+		decl.Public = true
+		t, err = p.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.tok == equilex.External {
+		decl.External = true
+		t, err = p.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if t.tok != equilex.Procedure {
+		return nil, fmt.Errorf("%s: expected procedure, got %q", t.span.Start, t.lit)
+	}
+
+	name, err := p.expect(equilex.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	decl.Name = name.lit
+
+	if _, err := p.expect(equilex.LeftParen); err != nil {
+		return nil, err
+	}
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+	decl.Params = params
+
+	body, err := p.parseBlockBody(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	decl.Body = body
+
+	end, err := p.expect(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	decl.EndPos = end.span.End
+
+	return decl, nil
+}
+
+// parseParams parses a procedure's `(name type, name type, ...)` formal
+// parameter list. The opening LeftParen has already been consumed.
+func (p *parser) parseParams() ([]ast.Param, error) {
+	var params []ast.Param
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok == equilex.RightParen {
+		p.next()
+		return params, nil
+	}
+
+	for {
+		name, err := p.expect(equilex.Identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		typ, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		switch typ.tok {
+		case equilex.String, equilex.Logical, equilex.Number, equilex.Date:
+		default:
+			return nil, fmt.Errorf("%s: expected a type keyword, got %q", typ.span.Start, typ.lit)
+		}
+
+		params = append(params, ast.Param{Name: name.lit, Type: typ.tok})
+
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok == equilex.RightParen {
+			return params, nil
+		}
+		if t.tok != equilex.Comma {
+			return nil, fmt.Errorf("%s: expected , or ) in parameter list, got %q", t.span.Start, t.lit)
+		}
+	}
+}
+
+func (p *parser) parseIf(start token) (*ast.IfStmt, error) {
+	stmt := &ast.IfStmt{StartPos: start.span.Start}
+
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Cond = cond
+
+	if _, err := p.expect(equilex.Then); err != nil {
+		return nil, err
+	}
+
+	then, err := p.parseBlockBody(equilex.ElseIf, equilex.Else, equilex.EndIf)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Then = then
+
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok != equilex.ElseIf {
+			break
+		}
+		p.next()
+
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(equilex.Then); err != nil {
+			return nil, err
+		}
+		body, err := p.parseBlockBody(equilex.ElseIf, equilex.Else, equilex.EndIf)
+		if err != nil {
+			return nil, err
+		}
+		stmt.ElseIfs = append(stmt.ElseIfs, ast.ElseIfClause{Cond: cond, Body: body})
+	}
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok == equilex.Else {
+		p.next()
+		elseBody, err := p.parseBlockBody(equilex.EndIf)
+		if err != nil {
+			return nil, err
+		}
+		stmt.Else = elseBody
+	}
+
+	end, err := p.expect(equilex.EndIf)
+	if err != nil {
+		return nil, err
+	}
+	stmt.EndPos = end.span.End
+
+	return stmt, nil
+}
+
+func (p *parser) parseWhile(start token) (*ast.WhileStmt, error) {
+	stmt := &ast.WhileStmt{StartPos: start.span.Start}
+
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Cond = cond
+
+	body, err := p.parseBlockBody(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Body = body
+
+	end, err := p.expect(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	stmt.EndPos = end.span.End
+
+	return stmt, nil
+}
+
+func (p *parser) parseRepeat(start token) (*ast.RepeatStmt, error) {
+	stmt := &ast.RepeatStmt{StartPos: start.span.Start}
+
+	body, err := p.parseBlockBody(equilex.Until)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Body = body
+
+	if _, err := p.expect(equilex.Until); err != nil {
+		return nil, err
+	}
+
+	until, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Until = until
+	stmt.EndPos = until.End()
+
+	return stmt, nil
+}
+
+func (p *parser) parseFor(start token) (*ast.ForStmt, error) {
+	stmt := &ast.ForStmt{StartPos: start.span.Start}
+
+	name, err := p.expect(equilex.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Var = name.lit
+
+	if _, err := p.expect(equilex.Equals); err != nil {
+		return nil, err
+	}
+
+	from, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.From = from
+
+	// "to" is not a reserved word in equilex.Token (see the TODO in
+	// lexer.go), so it comes through as a plain Identifier.
+	if err := p.expectIdentLit("to"); err != nil {
+		return nil, err
+	}
+
+	to, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.To = to
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok == equilex.Step {
+		p.next()
+		step, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Step = step
+	}
+
+	body, err := p.parseBlockBody(equilex.Next)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Body = body
+
+	end, err := p.expect(equilex.Next)
+	if err != nil {
+		return nil, err
+	}
+	stmt.EndPos = end.span.End
+
+	return stmt, nil
+}
+
+func (p *parser) parseSwitch(start token) (*ast.SwitchStmt, error) {
+	stmt := &ast.SwitchStmt{StartPos: start.span.Start}
+
+	tag, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	stmt.Tag = tag
+
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.tok == equilex.End {
+			p.next()
+			stmt.EndPos = t.span.End
+			return stmt, nil
+		}
+		if _, err := p.expect(equilex.Case); err != nil {
+			return nil, err
+		}
+
+		var values []ast.Expr
+		for {
+			v, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+
+			t, err := p.peek()
+			if err != nil {
+				return nil, err
+			}
+			if t.tok != equilex.Comma {
+				break
+			}
+			p.next()
+		}
+
+		body, err := p.parseBlockBody(equilex.Case, equilex.End)
+		if err != nil {
+			return nil, err
+		}
+
+		stmt.Cases = append(stmt.Cases, ast.CaseClause{Values: values, Body: body})
+	}
+}
+
+func (p *parser) parseBlock(start token) (*ast.BlockStmt, error) {
+	stmt := &ast.BlockStmt{StartPos: start.span.Start}
+
+	body, err := p.parseBlockBody(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Body = body
+
+	end, err := p.expect(equilex.End)
+	if err != nil {
+		return nil, err
+	}
+	stmt.EndPos = end.span.End
+
+	return stmt, nil
+}
+
+// parseCall parses a `KEYWORD name(args)` invocation such as
+// `method DoThing(1, "x")`.
+func (p *parser) parseCall(start token) (*ast.CallStmt, error) {
+	stmt := &ast.CallStmt{StartPos: start.span.Start, Kind: start.tok}
+
+	name, err := p.expect(equilex.Identifier)
+	if err != nil {
+		return nil, err
+	}
+	stmt.Name = name.lit
+	stmt.EndPos = name.span.End
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok != equilex.LeftParen {
+		return stmt, nil
+	}
+	p.next()
+
+	t, err = p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok != equilex.RightParen {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Args = append(stmt.Args, arg)
+
+			t, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if t.tok == equilex.RightParen {
+				stmt.EndPos = t.span.End
+				return stmt, nil
+			}
+			if t.tok != equilex.Comma {
+				return nil, fmt.Errorf("%s: expected , or ) in argument list, got %q", t.span.Start, t.lit)
+			}
+		}
+	}
+
+	rparen, err := p.expect(equilex.RightParen)
+	if err != nil {
+		return nil, err
+	}
+	stmt.EndPos = rparen.span.End
+
+	return stmt, nil
+}
+
+// parseExprStatement parses a bare expression statement, most commonly an
+// assignment of the form `X = Y`.
+func (p *parser) parseExprStatement() (*ast.ExprStmt, error) {
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.tok == equilex.Equals {
+		p.next()
+		y, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &ast.ExprStmt{X: &ast.BinaryExpr{Op: equilex.Equals, X: x, Y: y}}, nil
+	}
+
+	return &ast.ExprStmt{X: x}, nil
+}
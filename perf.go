@@ -0,0 +1,324 @@
+package equilex
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// letterTable and digitTable are byte-indexed lookup tables for the ASCII
+// fast path, modeled on the same idea as Vitess's Tokenizer: a table
+// lookup is cheaper than the two range comparisons isLetter/isDigit used
+// to do, and every call on the hot path (scanIdentifier, scanNumber, and
+// now ScanRef) goes through them.
+var letterTable [256]bool
+var digitTable [256]bool
+
+func init() {
+	for c := byte('a'); c <= 'z'; c++ {
+		letterTable[c] = true
+	}
+	for c := byte('A'); c <= 'Z'; c++ {
+		letterTable[c] = true
+	}
+	for c := byte('0'); c <= '9'; c++ {
+		digitTable[c] = true
+	}
+}
+
+func isLetter(ch rune) bool {
+	return ch >= 0 && ch < 256 && letterTable[byte(ch)]
+}
+
+func isDigit(ch rune) bool {
+	return ch >= 0 && ch < 256 && digitTable[byte(ch)]
+}
+
+// keywordByUpper is the same keyword set scanIdentifier's switch
+// recognises, as a lookup table ScanRef's buffer-slice fast path can
+// consult without building an intermediate string through bytes.Buffer.
+var keywordByUpper = map[string]Token{
+	"SUBTABLE": Subtable, "FINDRECORD": FindRecord, "FILEOPEN": FileOpen,
+	"FILEPRINT": FilePrint, "FIRSTRECORD": FirstRecord, "METHOD": Method,
+	"TEXT": Text, "LOOKUP": Lookup, "ALERT": Alert, "SETINDEX": SetIndex,
+	"EXECUTE": Execute, "METHODSWAP": MethodSwap, "METHODSETUP": MethodSetup,
+	"PROCESS": Process, "FORMSWAP": FormSwap, "FORM": Form,
+	"OPTIMISETABLE": OptimiseTable, "OPTIMISETABLEINDEXES": OptimiseTableIndexes,
+	"OPTIMISEDATABASE": OptimiseDatabase, "OPTIMISEDATABASEINDEXES": OptimiseDatabaseIndexes,
+	"OPTIMISEALLDATABASES": OptimiseAllDatabases, "OPTIMISEALLDATABASESINDEXES": OptimiseAllDatabasesIndexes,
+	"OPTIMISEDATABASEHELPER": OptimiseDatabase, "CONVERTALLDATABASES": ConvertAllDatabases,
+	"COMMAND": Command, "TASK": Task, "SHELL": Shell, "EXPORT": Export,
+	"IMPORT": Import, "EMPTYDATABASE": EmptyDatabase, "QUERY": Query,
+	"REPORTPREVIEW": ReportPreview, "REPORT": Report, "SYSTEM": System,
+	"PUBLIC": Public, "PROCEDURE": Procedure, "EXTERNAL": External,
+	"NOT": Not,
+	"IF":  If, "ELSE": Else, "ELSEIF": ElseIf, "ENDIF": EndIf,
+	"WHILE": While, "END": End, "REPEAT": Repeat, "UNTIL": Until,
+	"FOR": For, "NEXT": Next, "STEP": Step, "THEN": Then,
+	"BLOCK": Block, "SWITCH": Switch, "CASE": Case,
+	"AND": And, "OR": Or, "XOR": Xor,
+	"STRING": String, "LOGICAL": Logical, "DATE": Date, "NUMBER": Number,
+	"TRUE": True, "FALSE": False, "TODAY": Today, "SYSERROR": SysError,
+}
+
+// TokenRef is a zero-copy view of a token's literal: the half-open byte
+// range [Start, End) into the Lexer's buf. It is only meaningful for a
+// Lexer that has one, i.e. one built by NewLexer or NewBytesLexer; see
+// Bytes and Lit.
+type TokenRef struct {
+	Start, End int
+}
+
+// Bytes returns the literal ref identifies as a slice of the Lexer's own
+// buffer: no copy, no allocation. The slice aliases the Lexer's buffer, so
+// callers that need to keep it around past the Lexer's lifetime (or that
+// might mutate it) should copy it first.
+func (s *Lexer) Bytes(ref TokenRef) []byte {
+	return s.buf[ref.Start:ref.End]
+}
+
+// Lit materializes ref as a string. Unlike Bytes this does allocate --
+// converting a []byte to a string always copies -- so prefer Bytes on a
+// hot path that can work directly on bytes.
+func (s *Lexer) Lit(ref TokenRef) string {
+	return string(s.Bytes(ref))
+}
+
+// NewBytesLexer returns a Lexer over b without copying b into a fresh
+// buffer: ScanRef scans b directly, while Scan and ScanWithPos read it
+// through a bufio.Reader wrapped around b. It's for callers who already have the
+// whole source in memory, which is the common case for a tool that lexes
+// one complete file or string at a time, and who can use ScanRef to avoid
+// allocating a string per token as well.
+//
+// Scan and ScanWithPos both still work on a Lexer built this way (they're
+// unchanged; see NewLexer), but ScanRef does not work on a Lexer built
+// with plain NewLexer on a partially-unreadable io.Reader -- in that rare
+// fallback case buf is nil and ScanRef returns an error.
+func NewBytesLexer(b []byte) *Lexer {
+	return &Lexer{
+		r:   bufio.NewReader(bytes.NewReader(b)),
+		buf: b,
+		pos: Position{Line: 1, Column: 1, Offset: 0},
+	}
+}
+
+// readBufRune decodes the rune at s.pos.Offset directly out of s.buf,
+// advancing position exactly like read() does, without going through the
+// bufio.Reader at all. It is the primitive ScanRef's fast path is built
+// on; do not call it on a Lexer whose buf is nil.
+func (s *Lexer) readBufRune() rune {
+	if s.pos.Offset >= len(s.buf) {
+		return eof
+	}
+	ch, size := utf8.DecodeRune(s.buf[s.pos.Offset:])
+	s.prevPos = s.pos
+	s.prevCR = s.cr
+	s.advance(ch, size)
+	return ch
+}
+
+// unreadBufRune is readBufRune's single-level-lookback undo, the buf
+// analogue of unread().
+func (s *Lexer) unreadBufRune() {
+	s.pos = s.prevPos
+	s.cr = s.prevCR
+}
+
+// ScanRef is an alternative to Scan/ScanWithPos for a Lexer built with
+// NewLexer or NewBytesLexer: it scans directly over buf using readBufRune
+// instead of going through read()/unread() and the bufio.Reader, and
+// returns each token's literal as a TokenRef (an offset into buf) instead
+// of a freshly allocated string, which is where almost all of Scan's
+// allocations come from on a large, mostly-ASCII source tree.
+//
+// ScanRef does not replicate Scan's diagnostics: it doesn't distinguish a
+// malformed number from a well-formed one, and an unterminated quoted
+// literal or comment simply runs to EOF rather than reporting an error.
+// It's meant for the hot, well-formed-input path (a formatter or linter
+// re-scanning source it already knows parses); callers that need
+// first-class error reporting should use Scan or ScanWithPos instead.
+//
+// Do not interleave ScanRef calls with Scan/ScanWithPos calls on the same
+// Lexer: ScanRef never touches the underlying bufio.Reader, so mixing the
+// two would desynchronise it from s.pos.
+func (s *Lexer) ScanRef() (tok Token, ref TokenRef, err error) {
+	if s.buf == nil {
+		return Illegal, TokenRef{}, io.ErrNoProgress
+	}
+
+	start := s.pos.Offset
+	ch := s.readBufRune()
+
+	switch {
+	case isWhitespace(ch):
+		for {
+			ch = s.readBufRune()
+			if !isWhitespace(ch) {
+				break
+			}
+		}
+		if ch != eof {
+			s.unreadBufRune()
+		}
+		return WS, TokenRef{start, s.pos.Offset}, nil
+
+	case ch == '\n' || ch == '\r':
+		for {
+			ch = s.readBufRune()
+			if ch != '\n' && ch != '\r' {
+				break
+			}
+		}
+		if ch != eof {
+			s.unreadBufRune()
+		}
+		return NewLine, TokenRef{start, s.pos.Offset}, nil
+
+	case isDigit(ch):
+		token := IntegerConstant
+		for {
+			ch = s.readBufRune()
+			if ch == '.' && token == IntegerConstant {
+				token = DecimalConstant
+				continue
+			}
+			if isDigit(ch) {
+				continue
+			}
+			break
+		}
+		if ch != eof {
+			s.unreadBufRune()
+		}
+		return token, TokenRef{start, s.pos.Offset}, nil
+
+	case isLetter(ch) || ch == '_':
+		for {
+			ch = s.readBufRune()
+			if !isLetter(ch) && !isDigit(ch) && ch != '_' {
+				break
+			}
+		}
+		if ch != eof {
+			s.unreadBufRune()
+		}
+		end := s.pos.Offset
+		if kw, ok := keywordByUpper[strings.ToUpper(string(s.buf[start:end]))]; ok {
+			return kw, TokenRef{start, end}, nil
+		}
+		return Identifier, TokenRef{start, end}, nil
+
+	case ch == '"':
+		for {
+			ch = s.readBufRune()
+			if ch == '"' || ch == eof {
+				break
+			}
+		}
+		return StringConstant, TokenRef{start, s.pos.Offset}, nil
+
+	case ch == '\'':
+		for {
+			ch = s.readBufRune()
+			if ch == '\'' || ch == eof {
+				break
+			}
+		}
+		return DateOrTimeConstant, TokenRef{start, s.pos.Offset}, nil
+
+	case ch == '$':
+		for {
+			ch = s.readBufRune()
+			if ch == '$' || ch == eof {
+				break
+			}
+		}
+		return StringMultilineConstant, TokenRef{start, s.pos.Offset}, nil
+
+	case ch == '|':
+		if start+1 < len(s.buf) && s.buf[start+1] == '*' {
+			s.scanRefStandardComment(start)
+		} else {
+			s.scanRefSingleLineComment()
+		}
+		return Comment, TokenRef{start, s.pos.Offset}, nil
+	}
+
+	switch ch {
+	case eof:
+		return EOF, TokenRef{start, start}, nil
+	case ',':
+		return Comma, TokenRef{start, s.pos.Offset}, nil
+	case '=':
+		return Equals, TokenRef{start, s.pos.Offset}, nil
+	case '(':
+		return LeftParen, TokenRef{start, s.pos.Offset}, nil
+	case ')':
+		return RightParen, TokenRef{start, s.pos.Offset}, nil
+	case '[':
+		return LeftSquare, TokenRef{start, s.pos.Offset}, nil
+	case ']':
+		return RightSquare, TokenRef{start, s.pos.Offset}, nil
+	case '<':
+		return LeftAngle, TokenRef{start, s.pos.Offset}, nil
+	case '>':
+		return RightAngle, TokenRef{start, s.pos.Offset}, nil
+	case '+':
+		return Plus, TokenRef{start, s.pos.Offset}, nil
+	case '-':
+		return Minus, TokenRef{start, s.pos.Offset}, nil
+	case '*':
+		return Multiply, TokenRef{start, s.pos.Offset}, nil
+	case '/':
+		return Divide, TokenRef{start, s.pos.Offset}, nil
+	case '^':
+		return Power, TokenRef{start, s.pos.Offset}, nil
+	case '&':
+		return Ampersand, TokenRef{start, s.pos.Offset}, nil
+	case '.':
+		return Dot, TokenRef{start, s.pos.Offset}, nil
+	case ';':
+		return Semicolon, TokenRef{start, s.pos.Offset}, nil
+	case '\\':
+		return Backslash, TokenRef{start, s.pos.Offset}, nil
+	}
+
+	return Illegal, TokenRef{start, s.pos.Offset}, nil
+}
+
+func (s *Lexer) scanRefSingleLineComment() {
+	for {
+		ch := s.readBufRune()
+		if ch == eof {
+			return
+		}
+		if ch == '\n' {
+			s.unreadBufRune()
+			return
+		}
+	}
+}
+
+func (s *Lexer) scanRefStandardComment(start int) {
+	nest := 0
+	for {
+		pos := s.pos.Offset
+		ch := s.readBufRune()
+		if ch == eof {
+			return
+		}
+		switch {
+		case ch == '|' && s.pos.Offset < len(s.buf) && s.buf[s.pos.Offset] == '*':
+			nest++
+		case ch == '*' && pos-start > 1 && s.pos.Offset < len(s.buf) && s.buf[s.pos.Offset] == '|':
+			if nest == 0 {
+				s.readBufRune() // consume the closing '|'
+				return
+			}
+			nest--
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package equilex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestScanRefMatchesScan(t *testing.T) {
+	assert := assert.New(t)
+
+	src := `public procedure Foo()
+  if x = 1 then
+    alert("hi") |* greet *|
+  endif
+end`
+
+	slow := NewLexer(strings.NewReader(src))
+	fast := NewBytesLexer([]byte(src))
+
+	for {
+		wantTok, wantLit, wantErr := slow.Scan()
+		gotTok, ref, gotErr := fast.ScanRef()
+
+		assert.NoError(wantErr)
+		assert.NoError(gotErr)
+		assert.Equal(wantTok, gotTok)
+		assert.Equal(wantLit, fast.Lit(ref))
+
+		if wantTok == EOF {
+			break
+		}
+	}
+}
+
+func TestScanRefKeywordCaseInsensitive(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewBytesLexer([]byte("EndIf"))
+	tok, ref, err := l.ScanRef()
+	assert.NoError(err)
+	assert.Equal(EndIf, tok)
+	assert.Equal("EndIf", l.Lit(ref))
+}
+
+func TestScanRefOnReaderBuiltLexer(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("x = 1"))
+	tok, ref, err := l.ScanRef()
+	assert.NoError(err)
+	assert.Equal(Identifier, tok)
+	assert.Equal("x", l.Lit(ref))
+}
+
+func BenchmarkScanVsScanRef(b *testing.B) {
+	src := strings.Repeat(`if x = 1 then alert("hi") endif `, 200)
+
+	b.Run("Scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			l := NewLexer(strings.NewReader(src))
+			for {
+				tok, _, _ := l.Scan()
+				if tok == EOF {
+					break
+				}
+			}
+		}
+	})
+
+	b.Run("ScanRef", func(b *testing.B) {
+		buf := []byte(src)
+		for i := 0; i < b.N; i++ {
+			l := NewBytesLexer(buf)
+			for {
+				tok, _, _ := l.ScanRef()
+				if tok == EOF {
+					break
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+package equilex
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestScanWithPosTracksLineColumnOffset(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("foo\nbar"))
+
+	_, _, span, err := l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Position{Line: 1, Column: 1, Offset: 0}, span.Start)
+	assert.Equal(Position{Line: 1, Column: 4, Offset: 3}, span.End)
+
+	_, _, span, err = l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Position{Line: 1, Column: 4, Offset: 3}, span.Start)
+	assert.Equal(Position{Line: 2, Column: 1, Offset: 4}, span.End)
+
+	_, _, span, err = l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Position{Line: 2, Column: 1, Offset: 4}, span.Start)
+	assert.Equal(Position{Line: 2, Column: 4, Offset: 7}, span.End)
+}
+
+func TestScanWithPosTreatsCRLFAsOneNewline(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("a\r\nb"))
+
+	_, _, _, err := l.ScanWithPos()
+	assert.NoError(err)
+
+	_, _, span, err := l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Position{Line: 1, Column: 2, Offset: 1}, span.Start)
+	assert.Equal(Position{Line: 2, Column: 1, Offset: 3}, span.End)
+
+	_, _, span, err = l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Position{Line: 2, Column: 1, Offset: 3}, span.Start)
+	assert.Equal(Position{Line: 2, Column: 2, Offset: 4}, span.End)
+}
+
+func TestScanWithPosIllegalRune(t *testing.T) {
+	assert := assert.New(t)
+
+	l := NewLexer(strings.NewReader("@"))
+
+	tok, _, span, err := l.ScanWithPos()
+	assert.NoError(err)
+	assert.Equal(Illegal, tok)
+	assert.Equal(Position{Line: 1, Column: 1, Offset: 0}, span.Start)
+	assert.Equal(Position{Line: 1, Column: 2, Offset: 1}, span.End)
+}
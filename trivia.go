@@ -0,0 +1,104 @@
+package equilex
+
+// Trivia is a single non-significant piece of source: a run of
+// whitespace, a run of newlines, or a comment.
+type Trivia struct {
+	Tok  Token // WS, NewLine or Comment
+	Lit  string
+	Span Span
+}
+
+// TokenWithTrivia is one significant (non-trivia) token together with the
+// trivia that surrounds it: Leading holds everything since the previous
+// significant token, Trailing holds whatever follows on the same source
+// line, up to and including the run of newlines that ends it (so any blank
+// lines straight after that line are part of it too). Together, walking a
+// file with ScanLossless and re-emitting every token and every piece of
+// Leading/Trailing trivia reproduces the original source byte-for-byte.
+type TokenWithTrivia struct {
+	Tok  Token
+	Lit  string
+	Span Span
+
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// IsTrivia reports whether tok is one of the token kinds ScanLossless
+// treats as trivia rather than a significant token.
+func IsTrivia(tok Token) bool {
+	switch tok {
+	case WS, NewLine, Comment:
+		return true
+	}
+	return false
+}
+
+// pendingTok buffers a single already-scanned raw token, so ScanLossless
+// can look one token past the end of a Trailing run and hand it back on
+// the next call.
+type pendingTok struct {
+	tok  Token
+	lit  string
+	span Span
+}
+
+// nextRaw returns the next raw token, trivia included, preferring a
+// previously buffered lookahead token over the lexer.
+func (s *Lexer) nextRaw() (Token, string, Span, error) {
+	if s.pending != nil {
+		t := *s.pending
+		s.pending = nil
+		return t.tok, t.lit, t.span, nil
+	}
+	return s.ScanWithPos()
+}
+
+// ScanLossless returns the next significant token together with every
+// piece of whitespace, newline and comment trivia attached to it: Leading
+// trivia is whatever precedes the token since the last significant token,
+// and Trailing trivia is same-line trivia that follows it (typically a
+// trailing comment and the run of newlines ending that line). Concatenating the
+// Leading trivia, the token literal and the Trailing trivia of every
+// TokenWithTrivia returned, in order, reproduces the input exactly -- this
+// is what lets equilex/format re-emit source without losing comments or
+// blank lines.
+func (s *Lexer) ScanLossless() (TokenWithTrivia, error) {
+	var leading []Trivia
+
+	tok, lit, span, err := s.nextRaw()
+	if err != nil {
+		return TokenWithTrivia{}, err
+	}
+	for IsTrivia(tok) {
+		leading = append(leading, Trivia{Tok: tok, Lit: lit, Span: span})
+		tok, lit, span, err = s.nextRaw()
+		if err != nil {
+			return TokenWithTrivia{}, err
+		}
+	}
+
+	result := TokenWithTrivia{Tok: tok, Lit: lit, Span: span, Leading: leading}
+	if tok == EOF {
+		return result, nil
+	}
+
+	for {
+		t2, lit2, span2, err := s.nextRaw()
+		if err != nil {
+			return result, err
+		}
+
+		switch t2 {
+		case WS, Comment:
+			result.Trailing = append(result.Trailing, Trivia{Tok: t2, Lit: lit2, Span: span2})
+			continue
+		case NewLine:
+			result.Trailing = append(result.Trailing, Trivia{Tok: t2, Lit: lit2, Span: span2})
+		default:
+			// Not trivia: it belongs to the next call to ScanLossless.
+			s.pending = &pendingTok{tok: t2, lit: lit2, span: span2}
+		}
+		return result, nil
+	}
+}
@@ -0,0 +1,34 @@
+package equilex
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanLosslessRoundTrip(t *testing.T) {
+	assert := assert.New(t)
+
+	src := "x = 1 |* trailing *|\n\n\ny = 2\n"
+
+	l := NewLexer(strings.NewReader(src))
+
+	var rebuilt strings.Builder
+	for {
+		twt, err := l.ScanLossless()
+		assert.NoError(err)
+		for _, triv := range twt.Leading {
+			rebuilt.WriteString(triv.Lit)
+		}
+		rebuilt.WriteString(twt.Lit)
+		for _, triv := range twt.Trailing {
+			rebuilt.WriteString(triv.Lit)
+		}
+		if twt.Tok == EOF {
+			break
+		}
+	}
+
+	assert.Equal(src, rebuilt.String())
+}